@@ -0,0 +1,154 @@
+package clientx
+
+//重连退避策略，避免在服务端异常期间以固定频率持续重连打挂服务端，
+//同时提供重连过程的可观测性（Stats/Hook/Done/Err）。
+//Reconnect backoff policies, avoiding hammering the server at a fixed rate
+//during outages, plus observability into the reconnect process (Stats/Hook/Done/Err).
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffPolicy 计算第attempt次重连（从0开始）前应该等待的时长，以及是否已经超过最大重试次数
+// BackoffPolicy computes how long to wait before the attempt-th reconnect (0-based),
+// and whether the maximum number of attempts has been exceeded
+type BackoffPolicy interface {
+	NextDelay(attempt int) time.Duration
+	MaxAttemptsReached(attempt int) bool
+}
+
+// ExponentialBackoff 是内置的指数退避策略：delay = min(Max, Initial*Multiplier^attempt)，
+// 并在[0, delay*Jitter)范围内叠加均匀分布的抖动
+// ExponentialBackoff is the built-in exponential backoff policy: delay = min(Max,
+// Initial*Multiplier^attempt), with uniform jitter in [0, delay*Jitter) added on top
+type ExponentialBackoff struct {
+	Initial     time.Duration
+	Max         time.Duration
+	Multiplier  float64
+	Jitter      float64 // 0~1之间，0表示不加抖动 //between 0 and 1, 0 means no jitter
+	MaxAttempts int      // 0表示不限制重试次数 //0 means unlimited attempts
+}
+
+// NextDelay 实现BackoffPolicy
+// NextDelay implements BackoffPolicy
+func (b ExponentialBackoff) NextDelay(attempt int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay += rand.Float64() * delay * b.Jitter
+	}
+	return time.Duration(delay)
+}
+
+// MaxAttemptsReached 实现BackoffPolicy
+// MaxAttemptsReached implements BackoffPolicy
+func (b ExponentialBackoff) MaxAttemptsReached(attempt int) bool {
+	return b.MaxAttempts > 0 && attempt >= b.MaxAttempts
+}
+
+// fixedIntervalPolicy 是未设置SetReconnectPolicy时使用的默认策略，行为与历史的固定间隔重连一致
+// fixedIntervalPolicy is the default policy used when SetReconnectPolicy has not been
+// called, preserving the historical fixed-interval reconnect behavior
+type fixedIntervalPolicy struct {
+	intvl func() time.Duration
+}
+
+func (f fixedIntervalPolicy) NextDelay(attempt int) time.Duration { return f.intvl() }
+func (f fixedIntervalPolicy) MaxAttemptsReached(attempt int) bool { return false }
+
+// ReconnectStats 是重连过程的可观测性快照
+// ReconnectStats is an observability snapshot of the reconnect process
+type ReconnectStats struct {
+	TotalAttempts int64     // 自Client创建以来累计的重连尝试次数 //cumulative reconnect attempts since the Client was created
+	CurrentStreak int       // 当前这一轮连续失败的重连次数，连接成功后清零 //consecutive reconnect attempts in the current outage, reset on success
+	LastSuccess   time.Time // 最近一次连接成功的时间 //time of the most recent successful connection
+}
+
+// SetReconnectPolicy 设置重连退避策略，需要在 StartWithContext 之前调用
+// SetReconnectPolicy sets the reconnect backoff policy, must be called before StartWithContext
+func (c *Client) SetReconnectPolicy(policy BackoffPolicy) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	c.reconnectPolicy = policy
+}
+
+// SetReconnectHook 设置重连钩子，每次重连尝试前都会被调用，可用于日志或上报指标
+// SetReconnectHook sets a hook invoked before every reconnect attempt, useful for logging or metrics
+func (c *Client) SetReconnectHook(hook func(attempt int, lastErr error, nextDelay time.Duration)) {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	c.reconnectHook = hook
+}
+
+// Stats 返回当前的重连统计快照
+// Stats returns the current reconnect statistics snapshot
+func (c *Client) Stats() ReconnectStats {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	return c.reconnectStats
+}
+
+// Done 返回一个在重连次数耗尽（达到MaxAttempts）后关闭的通道
+// Done returns a channel that is closed once reconnect attempts are exhausted (MaxAttempts reached)
+func (c *Client) Done() <-chan struct{} {
+	return c.exhaustedCh
+}
+
+// Err 返回重连耗尽后的终止错误，在此之前返回nil
+// Err returns the terminal error once reconnects are exhausted, nil before that
+func (c *Client) Err() error {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	return c.terminalErr
+}
+
+func (c *Client) reconnectPolicyOrDefault() BackoffPolicy {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	if c.reconnectPolicy != nil {
+		return c.reconnectPolicy
+	}
+	return fixedIntervalPolicy{intvl: func() time.Duration { return c.reconnectIntvl }}
+}
+
+// beginReconnectAttempt 记录一次新的重连尝试，返回本次尝试序号（从0开始）
+// beginReconnectAttempt records a new reconnect attempt, returning its 0-based attempt index
+func (c *Client) beginReconnectAttempt() int {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	c.reconnectStats.TotalAttempts++
+	c.reconnectStats.CurrentStreak++
+	return c.reconnectStats.CurrentStreak - 1
+}
+
+// resetReconnectStreak 在连接成功后清零当前的连续失败计数
+// resetReconnectStreak clears the current consecutive-failure count after a successful connection
+func (c *Client) resetReconnectStreak() {
+	c.reconnectMu.Lock()
+	defer c.reconnectMu.Unlock()
+	c.reconnectStats.CurrentStreak = 0
+	c.reconnectStats.LastSuccess = time.Now()
+}
+
+// runReconnectHook 调用用户设置的重连钩子（如果有）
+// runReconnectHook invokes the user-supplied reconnect hook, if any
+func (c *Client) runReconnectHook(attempt int, lastErr error, nextDelay time.Duration) {
+	c.reconnectMu.Lock()
+	hook := c.reconnectHook
+	c.reconnectMu.Unlock()
+	if hook != nil {
+		hook(attempt, lastErr, nextDelay)
+	}
+}
+
+// giveUpReconnect 在重连次数耗尽后记录终止错误并关闭Done()通道
+// giveUpReconnect records the terminal error and closes the Done() channel once reconnects are exhausted
+func (c *Client) giveUpReconnect(lastErr error) {
+	c.reconnectMu.Lock()
+	c.terminalErr = lastErr
+	c.reconnectMu.Unlock()
+	c.exhaustedOnce.Do(func() { close(c.exhaustedCh) })
+}
@@ -0,0 +1,62 @@
+package clientx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoffNextDelay(t *testing.T) {
+	b := ExponentialBackoff{
+		Initial:    100 * time.Millisecond,
+		Max:        time.Second,
+		Multiplier: 2,
+	}
+	assert.Equal(t, 100*time.Millisecond, b.NextDelay(0))
+	assert.Equal(t, 200*time.Millisecond, b.NextDelay(1))
+	assert.Equal(t, 400*time.Millisecond, b.NextDelay(2))
+	// 超过Max应该被截断
+	// exceeding Max should be clamped
+	assert.Equal(t, time.Second, b.NextDelay(10))
+}
+
+func TestExponentialBackoffMaxAttempts(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Millisecond, Multiplier: 1, MaxAttempts: 3}
+	assert.False(t, b.MaxAttemptsReached(0))
+	assert.False(t, b.MaxAttemptsReached(2))
+	assert.True(t, b.MaxAttemptsReached(3))
+}
+
+func TestClientStats(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	stats := client.Stats()
+	assert.Equal(t, int64(0), stats.TotalAttempts)
+
+	attempt := client.beginReconnectAttempt()
+	assert.Equal(t, 0, attempt)
+	stats = client.Stats()
+	assert.Equal(t, int64(1), stats.TotalAttempts)
+	assert.Equal(t, 1, stats.CurrentStreak)
+
+	client.resetReconnectStreak()
+	stats = client.Stats()
+	assert.Equal(t, 0, stats.CurrentStreak)
+}
+
+func TestClientDoneAndErr(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	select {
+	case <-client.Done():
+		t.Fatal("Done() should not be closed yet")
+	default:
+	}
+
+	client.giveUpReconnect(assert.AnError)
+	select {
+	case <-client.Done():
+	default:
+		t.Fatal("Done() should be closed after giveUpReconnect")
+	}
+	assert.ErrorIs(t, client.Err(), assert.AnError)
+}
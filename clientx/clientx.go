@@ -5,6 +5,8 @@ package clientx
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -13,6 +15,10 @@ import (
 	"github.com/aceld/zinx/znet"
 )
 
+// defaultSendQueueCapacity 默认的发送队列容量
+// defaultSendQueueCapacity is the default send queue capacity
+const defaultSendQueueCapacity = 128
+
 // Client 结构体封装了 Zinx 客户端，提供了额外的连接管理功能
 // Client struct encapsulates the Zinx client, providing additional connection management features
 type Client struct {
@@ -23,6 +29,40 @@ type Client struct {
 	connectOkCh    chan struct{}
 	connected      atomic.Value
 	reconnectIntvl time.Duration
+
+	// 离线发送队列相关字段
+	// offline send queue fields
+	sendQueueMu    sync.RWMutex
+	msgChannel     chan *msgPacket
+	overflowPolicy OverflowPolicy
+	inflight       int32 // atomic，sendLoop已取出但deliver尚未返回的消息数 //atomic, packets dequeued by sendLoop whose deliver call hasn't returned yet
+	connGateMu     sync.Mutex
+	connGate       chan struct{}
+
+	// 会话属性相关字段，跨重连保留
+	// session property fields, preserved across reconnects
+	sessionMu         sync.RWMutex
+	sessionProperties map[string]any
+	onReattachMu      sync.Mutex
+	onReattach        func(conn ziface.IConnection, isReconnect bool)
+	everConnected     atomic.Value
+
+	// 重连退避策略及可观测性相关字段
+	// reconnect backoff policy and observability fields
+	reconnectMu     sync.Mutex
+	reconnectPolicy BackoffPolicy
+	reconnectHook   func(attempt int, lastErr error, nextDelay time.Duration)
+	reconnectStats  ReconnectStats
+	terminalErr     error
+	exhaustedCh     chan struct{}
+	exhaustedOnce   sync.Once
+
+	// 心跳保活相关字段
+	// heartbeat keepalive fields
+	heartbeatMu         sync.Mutex
+	heartbeatCfg        *HeartbeatConfig
+	heartbeatCancel     context.CancelFunc
+	heartbeatActivityCh chan struct{}
 }
 
 // NewClient 创建一个新的客户端实例, 参数保持与znet.NewClient一致
@@ -32,8 +72,12 @@ func NewClient(host string, port int, opts ...znet.ClientOption) *Client {
 		disconnectCh:   make(chan struct{}, 1),
 		connectOkCh:    make(chan struct{}, 1),
 		reconnectIntvl: time.Second,
+		msgChannel:     make(chan *msgPacket, defaultSendQueueCapacity),
+		connGate:       make(chan struct{}),
+		exhaustedCh:    make(chan struct{}),
 	}
 	c.connected.Store(false)
+	c.everConnected.Store(false)
 	c.IClient = znet.NewClient(host, port, opts...)
 
 	c.IClient.SetOnConnStart(c.onConnStart) //默认设置连接成功的回调是往connectOkCh中发送消息
@@ -61,6 +105,21 @@ func (c *Client) SetOnConnStart(handler func(conn ziface.IConnection)) {
 // onConnStart internal method, notifies when connection is successful
 func (c *Client) onConnStart(conn ziface.IConnection) {
 	c.connected.Store(true)
+
+	isReconnect := c.everConnected.Load().(bool)
+	c.everConnected.Store(true)
+	c.resetReconnectStreak()         //连接成功，清零当前重连失败计数
+	c.restoreSessionProperties(conn) //重连后恢复会话属性到新的IConnection上
+	c.fireOnReattach(conn, isReconnect)
+
+	// 必须等会话属性恢复、OnReattach（重新登录/订阅）跑完之后再放行发送队列，
+	// 否则排队的业务消息可能抢在重新鉴权之前就发出去了
+	// must wait until session properties are restored and OnReattach (re-login/
+	// re-subscribe) has run before releasing the send queue, otherwise queued
+	// business messages could race ahead of re-authentication
+	c.openConnGate()       //唤醒正在等待连接的写协程，开始flush离线发送队列
+	c.startHeartbeat(conn) //连接建立后启动心跳协程
+
 	select {
 	case c.connectOkCh <- struct{}{}:
 	default:
@@ -88,6 +147,8 @@ func (c *Client) SetOnConnStop(handler func(conn ziface.IConnection)) {
 // onConnStop internal method, notifies when connection is terminated
 func (c *Client) onConnStop(conn ziface.IConnection) {
 	c.connected.Store(false)
+	c.closeConnGate() //断线后阻塞写协程，待重连成功后再继续发送
+	c.stopHeartbeat() //断线后取消心跳协程，避免跨重连泄漏
 	select {
 	case c.disconnectCh <- struct{}{}:
 	default:
@@ -112,6 +173,8 @@ func (c *Client) StartWithContext(ctx context.Context) {
 	c.ctx, c.cancel = context.WithCancel(ctx)
 	c.IClient.Start()
 
+	go c.sendLoop() //启动专门的写协程，负责消费离线发送队列
+
 	go func() {
 		for {
 			select {
@@ -121,19 +184,51 @@ func (c *Client) StartWithContext(ctx context.Context) {
 				}
 				return
 			case <-c.disconnectCh:
-				zlog.Errorf("%v->%v, disconnect, reconnect after %v", c.Conn().LocalAddr(), c.Conn().RemoteAddr(), c.reconnectIntvl)
+				var lastErr error
+				if conn := c.Conn(); conn != nil {
+					lastErr = fmt.Errorf("%v->%v disconnected", conn.LocalAddr(), conn.RemoteAddr())
+				} else {
+					lastErr = errors.New("disconnected")
+				}
 				c.clearConnectOkCh() //清除connectOkCh中的消息，避免connectOkCh残留消息导致误认为连接成功
-				time.Sleep(c.reconnectIntvl)
-				c.IClient.Restart()
+				if !c.reconnect(lastErr) {
+					return
+				}
 			case err := <-c.GetErrChan():
-				zlog.Errorf("dial err:%v, reconnect after %v", err, c.reconnectIntvl)
-				time.Sleep(c.reconnectIntvl)
-				c.IClient.Restart()
+				if !c.reconnect(err) {
+					return
+				}
 			}
 		}
 	}()
 }
 
+// reconnect 按照重连退避策略等待后发起一次Restart，返回false表示重连次数已耗尽、该停止重连循环了
+// reconnect waits according to the backoff policy and then issues a Restart; returns false
+// once reconnect attempts are exhausted and the reconnect loop should stop
+func (c *Client) reconnect(lastErr error) bool {
+	policy := c.reconnectPolicyOrDefault()
+	attempt := c.beginReconnectAttempt()
+
+	if policy.MaxAttemptsReached(attempt) {
+		zlog.Errorf("clientx: max reconnect attempts reached, lastErr:%v", lastErr)
+		c.giveUpReconnect(lastErr)
+		return false
+	}
+
+	delay := policy.NextDelay(attempt)
+	c.runReconnectHook(attempt, lastErr, delay)
+	zlog.Errorf("clientx: reconnect attempt %d, lastErr:%v, reconnect after %v", attempt, lastErr, delay)
+
+	select {
+	case <-time.After(delay):
+	case <-c.ctx.Done():
+		return false
+	}
+	c.IClient.Restart()
+	return true
+}
+
 // Connect 同步连接服务器，直到连接成功或者取消连接
 // Connect synchronously connects to the server until the connection is successful or cancelled
 func (c *Client) Connect(ctx context.Context) error {
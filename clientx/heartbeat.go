@@ -0,0 +1,155 @@
+package clientx
+
+//心跳保活，替代用户在onConnStart里手写的pingLoop（参见示例zinx_clientx/main.go），
+//并在超时未收到任何入站流量或匹配的心跳应答时主动断开连接，交由重连逻辑处理。
+//Built-in heartbeat keepalive, replacing the hand-rolled pingLoop users would
+//otherwise start in onConnStart (see examples/zinx_clientx/main.go), proactively
+//stopping the connection on timeout so the reconnect loop takes over.
+import (
+	"context"
+	"time"
+
+	"github.com/aceld/zinx/ziface"
+	"github.com/aceld/zinx/zlog"
+)
+
+// HeartbeatConfig 配置心跳的发送内容、频率以及超时行为
+// HeartbeatConfig configures what the heartbeat sends, how often, and its timeout behavior
+type HeartbeatConfig struct {
+	MsgID    uint32
+	Interval time.Duration
+	Timeout  time.Duration
+	Payload  []byte
+
+	// AckMatcher 可选，用于判断一个入站IRequest是否是心跳应答；
+	// 如果设置了AckMatcher，只有匹配的请求才会重置超时计时；
+	// 如果不设置，调用NotifyInboundActivity时的任意入站流量都会重置计时
+	// AckMatcher is optional and decides whether an inbound IRequest is a heartbeat
+	// reply; if set, only matching requests reset the timeout deadline; if nil, any
+	// inbound traffic passed to NotifyInboundActivity resets it
+	AckMatcher func(request ziface.IRequest) bool
+
+	// OnTimeout 在超时仍未检测到存活信号时调用，随后Client会主动Stop当前连接以触发重连
+	// OnTimeout is invoked once the deadline is missed, right before the Client
+	// proactively stops the current connection to force the reconnect path
+	OnTimeout func(conn ziface.IConnection)
+}
+
+// EnableHeartbeat 开启心跳保活，每次连接建立（首次或重连）后都会按配置自动运行；
+// Interval和Timeout都必须为正数，否则会记录错误日志并放弃本次开启（保留之前的配置），
+// 避免heartbeatLoop里的time.NewTicker/NewTimer因非正数间隔而panic
+// EnableHeartbeat turns on heartbeat keepalive; it runs automatically after every
+// connection establishment (initial or reconnect) per the given config. Interval and
+// Timeout must both be positive, otherwise an error is logged and this call is
+// rejected (leaving any previous config in place), so heartbeatLoop's
+// time.NewTicker/NewTimer never panics on a non-positive interval
+func (c *Client) EnableHeartbeat(cfg HeartbeatConfig) {
+	if cfg.Interval <= 0 {
+		zlog.Errorf("clientx: EnableHeartbeat requires a positive Interval, got %v; heartbeat not enabled", cfg.Interval)
+		return
+	}
+	if cfg.Timeout <= 0 {
+		zlog.Errorf("clientx: EnableHeartbeat requires a positive Timeout, got %v; heartbeat not enabled", cfg.Timeout)
+		return
+	}
+
+	c.heartbeatMu.Lock()
+	defer c.heartbeatMu.Unlock()
+	cfgCopy := cfg
+	c.heartbeatCfg = &cfgCopy
+}
+
+// NotifyInboundActivity 由应用在自己的路由器里针对入站消息调用，用于喂给心跳检测存活信号
+// NotifyInboundActivity is called by the application's router for inbound messages,
+// feeding the heartbeat detector a liveness signal
+func (c *Client) NotifyInboundActivity(request ziface.IRequest) {
+	c.heartbeatMu.Lock()
+	cfg := c.heartbeatCfg
+	ch := c.heartbeatActivityCh
+	c.heartbeatMu.Unlock()
+
+	if cfg == nil || ch == nil {
+		return
+	}
+	if cfg.AckMatcher != nil && !cfg.AckMatcher(request) {
+		return
+	}
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// startHeartbeat 在连接建立后启动心跳协程，生命周期绑定到本次连接
+// startHeartbeat starts the heartbeat goroutine after a connection is established,
+// its lifetime bound to this particular connection
+func (c *Client) startHeartbeat(conn ziface.IConnection) {
+	c.heartbeatMu.Lock()
+	cfg := c.heartbeatCfg
+	if cfg == nil {
+		c.heartbeatMu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.heartbeatCancel = cancel
+	c.heartbeatActivityCh = make(chan struct{}, 1)
+	c.heartbeatMu.Unlock()
+
+	go c.heartbeatLoop(ctx, conn, cfg)
+}
+
+// stopHeartbeat 在连接断开时取消心跳协程，避免其跨重连泄漏
+// stopHeartbeat cancels the heartbeat goroutine on disconnect, so it does not leak across reconnects
+func (c *Client) stopHeartbeat() {
+	c.heartbeatMu.Lock()
+	cancel := c.heartbeatCancel
+	c.heartbeatCancel = nil
+	c.heartbeatMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (c *Client) heartbeatLoop(ctx context.Context, conn ziface.IConnection, cfg *HeartbeatConfig) {
+	sendTicker := time.NewTicker(cfg.Interval)
+	defer sendTicker.Stop()
+
+	deadline := time.NewTimer(cfg.Timeout)
+	defer deadline.Stop()
+
+	c.heartbeatMu.Lock()
+	activityCh := c.heartbeatActivityCh
+	c.heartbeatMu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sendTicker.C:
+			// 心跳消息直接通过底层连接发送，不经过离线发送队列，
+			// 否则断线时心跳会被缓冲，无法尽快探测到连接已经失活
+			// heartbeats bypass the offline send queue and go straight through the
+			// underlying connection, otherwise they'd be buffered while disconnected
+			// and fail to promptly detect a dead peer
+			if err := conn.SendMsg(cfg.MsgID, cfg.Payload); err != nil {
+				zlog.Errorf("clientx: heartbeat send failed, err:%v", err)
+			}
+		case <-activityCh:
+			if !deadline.Stop() {
+				select {
+				case <-deadline.C:
+				default:
+				}
+			}
+			deadline.Reset(cfg.Timeout)
+		case <-deadline.C:
+			zlog.Errorf("clientx: heartbeat timeout, no activity within %v, stop the connection", cfg.Timeout)
+			if cfg.OnTimeout != nil {
+				cfg.OnTimeout(conn)
+			}
+			c.IClient.Stop() //主动断开，交由重连逻辑重新建立连接
+			return
+		}
+	}
+}
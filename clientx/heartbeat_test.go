@@ -0,0 +1,63 @@
+package clientx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aceld/zinx/ziface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableHeartbeat(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	assert.Nil(t, client.heartbeatCfg)
+
+	client.EnableHeartbeat(HeartbeatConfig{MsgID: 99, Interval: time.Second, Timeout: 3 * time.Second})
+	assert.NotNil(t, client.heartbeatCfg)
+	assert.Equal(t, uint32(99), client.heartbeatCfg.MsgID)
+}
+
+func TestEnableHeartbeatRejectsNonPositiveInterval(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.EnableHeartbeat(HeartbeatConfig{MsgID: 1, Timeout: time.Second})
+	assert.Nil(t, client.heartbeatCfg, "zero Interval must not enable heartbeat")
+}
+
+func TestEnableHeartbeatRejectsNonPositiveTimeout(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.EnableHeartbeat(HeartbeatConfig{MsgID: 1, Interval: time.Second})
+	assert.Nil(t, client.heartbeatCfg, "zero Timeout must not enable heartbeat")
+}
+
+func TestNotifyInboundActivityWithoutMatcher(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.EnableHeartbeat(HeartbeatConfig{MsgID: 1, Interval: time.Second, Timeout: 3 * time.Second})
+	client.heartbeatActivityCh = make(chan struct{}, 1)
+
+	client.NotifyInboundActivity(nil)
+	select {
+	case <-client.heartbeatActivityCh:
+	default:
+		t.Fatal("expected activity notification without AckMatcher")
+	}
+}
+
+func TestNotifyInboundActivityWithMatcher(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.EnableHeartbeat(HeartbeatConfig{
+		MsgID:    1,
+		Interval: time.Second,
+		Timeout:  3 * time.Second,
+		AckMatcher: func(request ziface.IRequest) bool {
+			return request != nil
+		},
+	})
+	client.heartbeatActivityCh = make(chan struct{}, 1)
+
+	client.NotifyInboundActivity(nil)
+	select {
+	case <-client.heartbeatActivityCh:
+		t.Fatal("AckMatcher rejected the request, should not notify")
+	default:
+	}
+}
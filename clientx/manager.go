@@ -0,0 +1,353 @@
+package clientx
+
+//ClientManager 管理一组outbound *Client，提供负载均衡和健康检查，
+//类似zinx服务端的连接管理器(ConnManager)，但面向客户端场景。
+//ClientManager manages a group of outbound *Client instances, providing load
+//balancing and health tracking, analogous to zinx's server-side connection
+//manager (ConnManager) but for the client side.
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aceld/zinx/znet"
+)
+
+// Strategy 定义从ClientManager中挑选Client的负载均衡策略
+// Strategy defines the load-balancing strategy used to pick a Client from the ClientManager
+type Strategy int
+
+const (
+	// RoundRobin 轮询
+	// RoundRobin selects clients in round-robin order
+	RoundRobin Strategy = iota
+	// Random 随机
+	// Random selects a client at random
+	Random
+	// LeastPending 选择发送队列积压最少的客户端
+	// LeastPending selects the client with the smallest send-queue backlog
+	LeastPending
+	// Hash 按key做哈希选择（对当前健康client数取模，非一致性哈希）
+	// Hash selects a client by hashing a caller-supplied key
+	Hash
+)
+
+// unhealthyErrThreshold 连续错误次数达到该值的client会被PickHealthy跳过
+// unhealthyErrThreshold is the consecutive error count at which a client is skipped by PickHealthy
+const unhealthyErrThreshold = 5
+
+// Endpoint 描述一个outbound连接目标
+// Endpoint describes an outbound connection target
+type Endpoint struct {
+	ID   string
+	Host string
+	Port int
+}
+
+// managedClient 包裹一个*Client并附带健康统计信息
+// managedClient wraps a *Client along with health bookkeeping
+type managedClient struct {
+	id       string
+	endpoint Endpoint
+	client   *Client
+	errCount int64 // atomic，连续错误计数 //atomic, consecutive error count
+	rttNanos int64 // atomic，最近一次成功发送的RTT采样（纳秒） //atomic, most recent successful-send RTT sample, in nanoseconds
+}
+
+// healthy 报告该client是否处于连接状态、连续错误数未超限，且（如果设置了maxRTT）最近一次RTT采样未超过maxRTT
+// healthy reports whether the client is connected, its consecutive error count is
+// within bounds, and (if maxRTT is set) its most recent RTT sample does not exceed it
+func (mc *managedClient) healthy(maxRTT time.Duration) bool {
+	if !mc.client.IsConnected() || atomic.LoadInt64(&mc.errCount) >= unhealthyErrThreshold {
+		return false
+	}
+	if maxRTT > 0 && time.Duration(atomic.LoadInt64(&mc.rttNanos)) > maxRTT {
+		return false
+	}
+	return true
+}
+
+// recordResult 记录一次发送的结果：err非nil则累加连续错误数，否则清零并在rtt>0时更新RTT采样
+// recordResult records the outcome of a send: a non-nil err bumps the consecutive
+// error count, otherwise it is cleared and the RTT sample is updated when rtt > 0
+func (mc *managedClient) recordResult(err error, rtt time.Duration) {
+	if err != nil {
+		atomic.AddInt64(&mc.errCount, 1)
+		return
+	}
+	atomic.StoreInt64(&mc.errCount, 0)
+	if rtt > 0 {
+		atomic.StoreInt64(&mc.rttNanos, int64(rtt))
+	}
+}
+
+// ClientManager 持有一组*Client，并按Strategy挑选健康的Client
+// ClientManager holds a set of *Client instances and picks healthy ones according to Strategy
+type ClientManager struct {
+	mu         sync.RWMutex
+	clients    map[string]*managedClient
+	order      []string // 维护稳定的遍历顺序，用于RoundRobin //maintains a stable iteration order for RoundRobin
+	strategy   Strategy
+	rrIdx      uint64
+	clientOpts []znet.ClientOption
+	ctx        context.Context
+	maxRTT     time.Duration // 0表示不做RTT健康检查 //0 disables the RTT health check
+}
+
+// SetMaxRTT 设置RTT健康阈值，PickHealthy/PickHealthyForKey/Broadcast会跳过最近一次
+// ReportResult上报的RTT超过该阈值的client；0（默认）表示不做RTT检查
+// SetMaxRTT sets the RTT health threshold; PickHealthy/PickHealthyForKey/Broadcast
+// skip any client whose most recent ReportResult RTT sample exceeds it. 0 (the
+// default) disables the RTT check
+func (m *ClientManager) SetMaxRTT(maxRTT time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxRTT = maxRTT
+}
+
+// NewClientManager 创建一个ClientManager，clientOpts会应用到管理器创建的每一个Client上
+// NewClientManager creates a ClientManager; clientOpts are applied to every Client the manager creates
+func NewClientManager(strategy Strategy, clientOpts ...znet.ClientOption) *ClientManager {
+	return &ClientManager{
+		clients:    make(map[string]*managedClient),
+		strategy:   strategy,
+		clientOpts: clientOpts,
+	}
+}
+
+// Start 启动管理器当前持有的所有Client，后续Add的Client也会使用同一个ctx启动
+// Start starts every Client currently held by the manager; Clients added afterwards
+// are started with the same ctx
+func (m *ClientManager) Start(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	clients := make([]*Client, 0, len(m.clients))
+	for _, mc := range m.clients {
+		clients = append(clients, mc.client)
+	}
+	m.mu.Unlock()
+
+	for _, c := range clients {
+		c.StartWithContext(ctx)
+	}
+}
+
+// Add 创建并注册一个到endpoint的新Client，如果管理器已经Start则立即启动该Client
+// Add creates and registers a new Client to endpoint; if the manager has already
+// Started, the new Client is started immediately
+func (m *ClientManager) Add(endpoint Endpoint) (*Client, error) {
+	m.mu.Lock()
+	if _, exists := m.clients[endpoint.ID]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("clientx: endpoint %q already exists", endpoint.ID)
+	}
+	client := NewClient(endpoint.Host, endpoint.Port, m.clientOpts...)
+	m.clients[endpoint.ID] = &managedClient{id: endpoint.ID, endpoint: endpoint, client: client}
+	m.order = append(m.order, endpoint.ID)
+	ctx := m.ctx
+	m.mu.Unlock()
+
+	if ctx != nil {
+		client.StartWithContext(ctx)
+	}
+	return client, nil
+}
+
+// Remove 停止并移除一个Client
+// Remove stops and unregisters a Client
+func (m *ClientManager) Remove(id string) {
+	m.mu.Lock()
+	mc, ok := m.clients[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.clients, id)
+	for i, existingID := range m.order {
+		if existingID == id {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	mc.client.Stop()
+}
+
+// Get 按id获取一个Client
+// Get returns a Client by id
+func (m *ClientManager) Get(id string) (*Client, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mc, ok := m.clients[id]
+	if !ok {
+		return nil, false
+	}
+	return mc.client, true
+}
+
+// Len 返回当前管理的Client数量
+// Len returns the number of Clients currently managed
+func (m *ClientManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.clients)
+}
+
+// ErrNoHealthyClient 在没有健康Client可选时返回
+// ErrNoHealthyClient is returned when no healthy Client is available
+var ErrNoHealthyClient = errors.New("clientx: no healthy client available")
+
+// PickHealthy 根据manager的Strategy挑选一个健康的Client，并返回其id，用于随后调用
+// ReportResult反馈这次使用的发送结果和RTT；Hash策略下请使用PickHealthyForKey
+// PickHealthy selects a healthy Client according to the manager's Strategy and
+// returns its id, to be passed back via ReportResult with the outcome and RTT of
+// whatever send the caller goes on to make; use PickHealthyForKey when the
+// Strategy is Hash
+func (m *ClientManager) PickHealthy() (client *Client, id string, err error) {
+	if m.strategy == Hash {
+		return nil, "", errors.New("clientx: Hash strategy requires PickHealthyForKey")
+	}
+	mc, err := m.pick(func(healthy []*managedClient) *managedClient {
+		switch m.strategy {
+		case Random:
+			return healthy[rand.Intn(len(healthy))]
+		case LeastPending:
+			best := healthy[0]
+			for _, cand := range healthy[1:] {
+				if cand.client.PendingCount() < best.client.PendingCount() {
+					best = cand
+				}
+			}
+			return best
+		default: // RoundRobin
+			idx := atomic.AddUint64(&m.rrIdx, 1)
+			return healthy[int(idx)%len(healthy)]
+		}
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return mc.client, mc.id, nil
+}
+
+// PickHealthyForKey 在Hash策略下按key选择一个健康的Client，同样返回其id供ReportResult使用
+// PickHealthyForKey selects a healthy Client by hashing key (used with the Hash
+// strategy), likewise returning its id for use with ReportResult
+func (m *ClientManager) PickHealthyForKey(key string) (client *Client, id string, err error) {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum32()
+	mc, err := m.pick(func(healthy []*managedClient) *managedClient {
+		return healthy[int(sum)%len(healthy)]
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return mc.client, mc.id, nil
+}
+
+// ReportResult 由调用方在使用PickHealthy/PickHealthyForKey挑出的client完成一次发送后调用，
+// 反馈这次发送是否成功以及RTT采样（rtt<=0表示没有RTT样本），驱动错误计数和RTT健康判断
+// ReportResult is called by the caller after a send made against the client
+// returned by PickHealthy/PickHealthyForKey completes, feeding back whether it
+// succeeded and an RTT sample (rtt<=0 means no RTT sample), driving the error
+// count and RTT health checks
+func (m *ClientManager) ReportResult(id string, sendErr error, rtt time.Duration) {
+	m.mu.RLock()
+	mc, ok := m.clients[id]
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+	mc.recordResult(sendErr, rtt)
+}
+
+func (m *ClientManager) pick(choose func([]*managedClient) *managedClient) (*managedClient, error) {
+	m.mu.RLock()
+	maxRTT := m.maxRTT
+	healthy := make([]*managedClient, 0, len(m.order))
+	for _, id := range m.order {
+		if mc, ok := m.clients[id]; ok && mc.healthy(maxRTT) {
+			healthy = append(healthy, mc)
+		}
+	}
+	m.mu.RUnlock()
+
+	if len(healthy) == 0 {
+		return nil, ErrNoHealthyClient
+	}
+	return choose(healthy), nil
+}
+
+// Broadcast 向所有健康的Client发送同一条消息，返回遇到的第一个错误（如果有）。
+// 发送走非阻塞投递，这样某一个发送队列已满的peer不会卡住整个广播。入队失败
+// （ErrSendQueueFull）只反映本地发送队列的瞬时积压，不代表对端不健康，因此不会经过
+// recordResult计入连续错误数——否则广播高峰期间一个暂时积压的健康peer会被
+// PickHealthy/PickHealthyForKey之后的普通单播也一并跳过
+// Broadcast sends the same message to every healthy Client, returning the first
+// error encountered, if any. The send uses a non-blocking enqueue so a single peer
+// with a full send queue cannot stall the whole broadcast. An enqueue failure
+// (ErrSendQueueFull) only reflects momentary local send-queue backpressure, not
+// remote-peer health, so it is never routed through recordResult — otherwise a
+// healthy peer that is briefly backlogged during a broadcast burst would also get
+// skipped by subsequent unicast sends via PickHealthy/PickHealthyForKey
+func (m *ClientManager) Broadcast(msgID uint32, data []byte) error {
+	m.mu.RLock()
+	maxRTT := m.maxRTT
+	managed := make([]*managedClient, 0, len(m.clients))
+	for _, mc := range m.clients {
+		managed = append(managed, mc)
+	}
+	m.mu.RUnlock()
+
+	var firstErr error
+	for _, mc := range managed {
+		if !mc.healthy(maxRTT) {
+			continue
+		}
+		if err := mc.client.enqueueNonBlocking(&msgPacket{msgID: msgID, data: data}); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// UpdateEndpoints 将当前管理的endpoint集合更新为endpoints，多余的Client会被优雅停止，
+// 新增的endpoint会用manager的ClientOption创建并启动
+// UpdateEndpoints reconciles the managed endpoint set with endpoints, gracefully
+// stopping removed Clients and starting new ones with the manager's ClientOptions
+func (m *ClientManager) UpdateEndpoints(endpoints []Endpoint) error {
+	wanted := make(map[string]Endpoint, len(endpoints))
+	for _, ep := range endpoints {
+		wanted[ep.ID] = ep
+	}
+
+	m.mu.RLock()
+	var toRemove []string
+	for id := range m.clients {
+		if _, ok := wanted[id]; !ok {
+			toRemove = append(toRemove, id)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, id := range toRemove {
+		m.Remove(id)
+	}
+
+	for _, ep := range endpoints {
+		if _, ok := m.Get(ep.ID); ok {
+			continue
+		}
+		if _, err := m.Add(ep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
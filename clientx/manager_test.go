@@ -0,0 +1,132 @@
+package clientx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientManagerAddGetRemove(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+
+	c, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9001})
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+	assert.Equal(t, 1, m.Len())
+
+	_, err = m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9001})
+	assert.Error(t, err)
+
+	got, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, c, got)
+
+	m.Remove("a")
+	assert.Equal(t, 0, m.Len())
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+}
+
+func TestClientManagerPickHealthyNoneAvailable(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+	_, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9002})
+	assert.NoError(t, err)
+
+	// 还未连接成功，不应该被认为是健康的
+	// not yet connected, should not be considered healthy
+	_, _, err = m.PickHealthy()
+	assert.ErrorIs(t, err, ErrNoHealthyClient)
+}
+
+func TestClientManagerHashRequiresKey(t *testing.T) {
+	m := NewClientManager(Hash)
+	_, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9003})
+	assert.NoError(t, err)
+
+	_, _, err = m.PickHealthy()
+	assert.Error(t, err)
+}
+
+func TestClientManagerReportResultTracksErrorsAndRTT(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+	_, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9004})
+	assert.NoError(t, err)
+
+	mc, ok := m.clients["a"]
+	assert.True(t, ok)
+	mc.client.connected.Store(true) // 模拟已连接 //simulate a connected client
+
+	m.ReportResult("a", nil, 20*time.Millisecond)
+	assert.Equal(t, int64(20*time.Millisecond), mc.rttNanos)
+
+	for i := 0; i < unhealthyErrThreshold; i++ {
+		m.ReportResult("a", assert.AnError, 0)
+	}
+	_, _, err = m.PickHealthy()
+	assert.ErrorIs(t, err, ErrNoHealthyClient, "errCount reaching threshold should mark client unhealthy")
+}
+
+func TestClientManagerSetMaxRTTSkipsSlowClients(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+	_, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9005})
+	assert.NoError(t, err)
+
+	mc, ok := m.clients["a"]
+	assert.True(t, ok)
+	mc.client.connected.Store(true)
+
+	m.SetMaxRTT(10 * time.Millisecond)
+	m.ReportResult("a", nil, 50*time.Millisecond)
+
+	_, _, err = m.PickHealthy()
+	assert.ErrorIs(t, err, ErrNoHealthyClient, "RTT above maxRTT should mark client unhealthy")
+}
+
+func TestClientManagerBroadcastDoesNotBlockOnFullQueue(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+	_, err := m.Add(Endpoint{ID: "slow", Host: "localhost", Port: 9006})
+	assert.NoError(t, err)
+	_, err = m.Add(Endpoint{ID: "fast", Host: "localhost", Port: 9007})
+	assert.NoError(t, err)
+
+	slow, _ := m.Get("slow")
+	slow.connected.Store(true)
+	slow.SetSendQueue(1, Block)
+	assert.NoError(t, slow.SendMsg(1, []byte("fill"))) // 填满slow的队列 //fill up slow's queue
+
+	fast, _ := m.Get("fast")
+	fast.connected.Store(true)
+
+	done := make(chan error, 1)
+	go func() { done <- m.Broadcast(2, []byte("hi")) }()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err, "slow peer's full queue should surface as an error, not a hang")
+	case <-time.After(time.Second):
+		t.Fatal("Broadcast blocked on a single full queue instead of skipping it")
+	}
+
+	assert.Equal(t, 1, fast.PendingCount(), "fast client should still receive the broadcast")
+}
+
+func TestClientManagerBroadcastDoesNotAffectHealthTracking(t *testing.T) {
+	m := NewClientManager(RoundRobin)
+	_, err := m.Add(Endpoint{ID: "a", Host: "localhost", Port: 9008})
+	assert.NoError(t, err)
+
+	c, _ := m.Get("a")
+	c.connected.Store(true)
+	c.SetSendQueue(1, Block)
+	// 填满队列，之后每次Broadcast的入队都会失败
+	// fill the queue so every subsequent Broadcast enqueue fails
+	assert.NoError(t, c.SendMsg(1, []byte("fill")))
+
+	for i := 0; i < unhealthyErrThreshold+2; i++ {
+		_ = m.Broadcast(2, []byte("hi"))
+	}
+
+	_, _, err = m.PickHealthy()
+	assert.NoError(t, err, "repeated queue-full broadcasts must not mark a healthy peer unhealthy")
+}
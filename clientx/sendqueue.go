@@ -0,0 +1,283 @@
+package clientx
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/aceld/zinx/zlog"
+)
+
+// OverflowPolicy 定义发送队列已满时的处理策略
+// OverflowPolicy defines how the send queue behaves when it is full
+type OverflowPolicy int
+
+const (
+	// Block 阻塞直到队列有空位（或ctx/客户端被取消）
+	// Block blocks the caller until room is available (or the client is stopped)
+	Block OverflowPolicy = iota
+	// DropOldest 丢弃队列中最旧的消息，为新消息腾出空间
+	// DropOldest drops the oldest queued message to make room for the new one
+	DropOldest
+	// DropNewest 直接丢弃当前要发送的消息
+	// DropNewest drops the message currently being sent
+	DropNewest
+	// Error 队列已满时直接返回错误
+	// Error returns an error immediately when the queue is full
+	Error
+)
+
+// ErrSendQueueFull 在 Error 策略下，队列已满时返回
+// ErrSendQueueFull is returned under the Error policy when the queue is full
+var ErrSendQueueFull = errors.New("clientx: send queue is full")
+
+// msgPacket 是发送队列中的一条待发消息
+// msgPacket is a single pending message held in the send queue
+type msgPacket struct {
+	msgID uint32
+	data  []byte
+	buff  bool //是否通过SendBuffMsg发送 //whether to send via SendBuffMsg
+}
+
+// SetSendQueue 设置发送队列容量和溢出策略，可以在StartWithContext之前或之后调用；
+// 切换到新队列时会把旧队列里尚未发送的消息按原顺序搬到新队列，超出新容量的消息会被丢弃并记录日志，
+// 随后关闭旧队列，唤醒可能正空闲阻塞在旧队列上的sendLoop，让它重新获取当前队列
+// SetSendQueue sets the send queue capacity and overflow policy; it may be called
+// either before or after StartWithContext. Switching to the new queue moves any
+// messages still pending in the old one into it in order; messages beyond the new
+// capacity are dropped and logged. It then closes the old queue to wake sendLoop if it is idle-blocked on it, so it
+// re-fetches the current queue instead of parking on the abandoned one forever
+func (c *Client) SetSendQueue(capacity int, policy OverflowPolicy) {
+	c.sendQueueMu.Lock()
+	defer c.sendQueueMu.Unlock()
+
+	newCh := make(chan *msgPacket, capacity)
+	old := c.msgChannel
+	c.msgChannel = newCh
+	c.overflowPolicy = policy
+
+drain:
+	for {
+		select {
+		case pkt := <-old:
+			select {
+			case newCh <- pkt:
+			default:
+				zlog.Errorf("clientx: new send queue capacity too small, drop pending msgID=%d", pkt.msgID)
+			}
+		default:
+			break drain
+		}
+	}
+	// 持有写锁期间完成排空和替换，此时不会有enqueue仍持有旧队列的读锁在往old发送，
+	// 关闭old是安全的
+	// the drain-and-swap above runs under the exclusive write lock, so no concurrent
+	// enqueue can still be holding a read lock and sending into old; closing it here
+	// is safe
+	close(old)
+}
+
+// SendMsg 将消息投递到发送队列，由专门的写协程异步发送
+// SendMsg enqueues a message onto the send queue, to be sent by the dedicated writer goroutine
+func (c *Client) SendMsg(msgID uint32, data []byte) error {
+	return c.enqueue(&msgPacket{msgID: msgID, data: data})
+}
+
+// SendBuffMsg 将消息投递到发送队列，通过 conn.SendBuffMsg 异步发送
+// SendBuffMsg enqueues a message onto the send queue, to be sent via conn.SendBuffMsg
+func (c *Client) SendBuffMsg(msgID uint32, data []byte) error {
+	return c.enqueue(&msgPacket{msgID: msgID, data: data, buff: true})
+}
+
+// enqueue 在持有读锁的情况下把pkt放入当前发送队列并完成整个投递动作（而不仅仅是快照
+// 出channel后就释放锁），这样SetSendQueue的写锁必须等待所有进行中的enqueue完成后才能
+// 拿到，从而保证它排空、替换、关闭旧队列时，不会有enqueue还在往这个旧队列发送
+// enqueue holds the read lock for the whole operation, not just a channel snapshot,
+// so SetSendQueue's write lock cannot be acquired until every in-flight enqueue has
+// finished — guaranteeing no enqueue is still sending into the old queue when
+// SetSendQueue drains, swaps and closes it
+func (c *Client) enqueue(pkt *msgPacket) error {
+	c.sendQueueMu.RLock()
+	defer c.sendQueueMu.RUnlock()
+	ch := c.msgChannel
+	policy := c.overflowPolicy
+
+	switch policy {
+	case DropNewest:
+		select {
+		case ch <- pkt:
+		default:
+			zlog.Errorf("clientx: send queue full, drop newest msgID=%d", pkt.msgID)
+		}
+		return nil
+	case DropOldest:
+		for {
+			select {
+			case ch <- pkt:
+				return nil
+			default:
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	case Error:
+		select {
+		case ch <- pkt:
+			return nil
+		default:
+			return ErrSendQueueFull
+		}
+	default: // Block
+		select {
+		case ch <- pkt:
+			return nil
+		case <-c.doneChan():
+			return errors.New("clientx: client stopped")
+		}
+	}
+}
+
+// PendingCount 返回发送队列中当前积压的消息数
+// PendingCount returns the number of messages currently backlogged in the send queue
+func (c *Client) PendingCount() int {
+	c.sendQueueMu.RLock()
+	defer c.sendQueueMu.RUnlock()
+	return len(c.msgChannel)
+}
+
+// enqueueNonBlocking 以非阻塞方式尝试把pkt放入当前发送队列，忽略client配置的溢出策略；
+// 用于像ClientManager.Broadcast这类不希望被某一个慢peer卡住的场景。和enqueue一样，
+// 全程持有读锁，避免与SetSendQueue的旧队列关闭发生竞争
+// enqueueNonBlocking attempts a non-blocking push onto the current send queue,
+// ignoring the client's configured overflow policy; used by callers such as
+// ClientManager.Broadcast that must not stall on a single slow peer. Like enqueue,
+// it holds the read lock for the whole operation to avoid racing with SetSendQueue
+// closing the old queue
+func (c *Client) enqueueNonBlocking(pkt *msgPacket) error {
+	c.sendQueueMu.RLock()
+	defer c.sendQueueMu.RUnlock()
+	select {
+	case c.msgChannel <- pkt:
+		return nil
+	default:
+		return ErrSendQueueFull
+	}
+}
+
+// Flush 阻塞直到发送队列清空、且被取出的消息都已经处理完（deliver返回），或者ctx被取消
+// Flush blocks until the send queue is empty and every dequeued packet has finished
+// being processed by deliver, or ctx is cancelled
+func (c *Client) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		c.sendQueueMu.RLock()
+		empty := len(c.msgChannel) == 0
+		c.sendQueueMu.RUnlock()
+		if empty && atomic.LoadInt32(&c.inflight) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// doneChan 返回客户端上下文的Done通道，在 StartWithContext 调用之前返回nil（永不触发）
+// doneChan returns the client context's Done channel; returns nil (never fires) before StartWithContext is called
+func (c *Client) doneChan() <-chan struct{} {
+	if c.ctx != nil {
+		return c.ctx.Done()
+	}
+	return nil
+}
+
+// currentMsgChannel 在持锁的情况下取出当前的发送队列，供sendLoop在select中使用，
+// 避免直接访问可能被SetSendQueue并发替换的c.msgChannel字段
+// currentMsgChannel snapshots the current send queue under lock for sendLoop's select,
+// avoiding a direct access to c.msgChannel which SetSendQueue may concurrently replace
+func (c *Client) currentMsgChannel() chan *msgPacket {
+	c.sendQueueMu.RLock()
+	defer c.sendQueueMu.RUnlock()
+	return c.msgChannel
+}
+
+// sendLoop 是专门的写协程，串行地从发送队列中取出消息并通过底层连接发出。
+// SetSendQueue替换队列时会关闭旧队列，使这里空闲阻塞的接收立即返回ok=false，
+// 从而在下一轮循环里重新获取当前队列，而不是永远阻塞在被丢弃的旧队列上
+// sendLoop is the dedicated writer goroutine draining the send queue and writing
+// through the underlying connection. When SetSendQueue swaps the queue it closes
+// the old one, so a receive idle-blocked here returns immediately with ok=false,
+// letting the next loop iteration re-fetch the current queue instead of parking on
+// the abandoned one forever
+func (c *Client) sendLoop() {
+	for {
+		select {
+		case <-c.doneChan():
+			return
+		case pkt, ok := <-c.currentMsgChannel():
+			if !ok {
+				continue
+			}
+			atomic.AddInt32(&c.inflight, 1)
+			c.waitConnected()
+			if err := c.deliver(pkt); err != nil {
+				zlog.Errorf("clientx: send msgID=%d failed, err:%v", pkt.msgID, err)
+			}
+			atomic.AddInt32(&c.inflight, -1)
+		}
+	}
+}
+
+func (c *Client) deliver(pkt *msgPacket) error {
+	conn := c.Conn()
+	if conn == nil {
+		return errors.New("clientx: connection is nil")
+	}
+	if pkt.buff {
+		return conn.SendBuffMsg(pkt.msgID, pkt.data)
+	}
+	return conn.SendMsg(pkt.msgID, pkt.data)
+}
+
+// waitConnected 阻塞直到客户端连接成功（或客户端被停止）
+// waitConnected blocks until the client is connected (or the client is stopped)
+func (c *Client) waitConnected() {
+	c.connGateMu.Lock()
+	gate := c.connGate
+	c.connGateMu.Unlock()
+	select {
+	case <-gate:
+	case <-c.doneChan():
+	}
+}
+
+// openConnGate 在连接成功时打开门禁，唤醒等待发送的写协程
+// openConnGate opens the gate on successful connection, waking up the writer goroutine
+func (c *Client) openConnGate() {
+	c.connGateMu.Lock()
+	defer c.connGateMu.Unlock()
+	select {
+	case <-c.connGate:
+		// already open
+	default:
+		close(c.connGate)
+	}
+}
+
+// closeConnGate 在连接断开时重置门禁，后续发送将被阻塞直到重连成功
+// closeConnGate resets the gate on disconnect, so subsequent sends block until the connection is restored
+func (c *Client) closeConnGate() {
+	c.connGateMu.Lock()
+	defer c.connGateMu.Unlock()
+	select {
+	case <-c.connGate:
+		c.connGate = make(chan struct{})
+	default:
+	}
+}
@@ -0,0 +1,103 @@
+package clientx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSendQueue(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(2, DropOldest)
+	assert.Equal(t, 2, cap(client.msgChannel))
+	assert.Equal(t, DropOldest, client.overflowPolicy)
+}
+
+func TestEnqueueDropNewest(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(1, DropNewest)
+
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+	// 队列已满，DropNewest策略下应直接丢弃而不报错
+	// queue is full, DropNewest policy should silently drop without error
+	assert.NoError(t, client.SendMsg(2, []byte("b")))
+	assert.Equal(t, 1, len(client.msgChannel))
+}
+
+func TestEnqueueError(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(1, Error)
+
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+	err := client.SendMsg(2, []byte("b"))
+	assert.ErrorIs(t, err, ErrSendQueueFull)
+}
+
+func TestEnqueueDropOldest(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(1, DropOldest)
+
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+	assert.NoError(t, client.SendMsg(2, []byte("b")))
+	assert.Equal(t, 1, len(client.msgChannel))
+	pkt := <-client.msgChannel
+	assert.Equal(t, uint32(2), pkt.msgID)
+}
+
+func TestSetSendQueueDrainsPendingMessages(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(4, Block)
+
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+	assert.NoError(t, client.SendMsg(2, []byte("b")))
+
+	// 重新设置队列不应该丢失已经入队的消息
+	// re-setting the queue should not lose messages already enqueued
+	client.SetSendQueue(4, Block)
+	assert.Equal(t, 2, len(client.msgChannel))
+	first := <-client.msgChannel
+	second := <-client.msgChannel
+	assert.Equal(t, uint32(1), first.msgID)
+	assert.Equal(t, uint32(2), second.msgID)
+}
+
+func TestFlushWaitsForInflightDelivery(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.SetSendQueue(4, Block)
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+
+	// 模拟sendLoop已经把消息取出、正在等待连接可用（deliver尚未返回）
+	// simulate sendLoop having dequeued the packet and being blocked waiting for
+	// a connection (deliver has not returned yet)
+	<-client.msgChannel
+	atomic.AddInt32(&client.inflight, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := client.Flush(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	atomic.AddInt32(&client.inflight, -1)
+	assert.NoError(t, client.Flush(context.Background()))
+}
+
+func TestSendLoopWakesAfterQueueSwapWhileIdle(t *testing.T) {
+	client := NewClient("localhost", 8080)
+	client.ctx, client.cancel = context.WithCancel(context.Background())
+	defer client.cancel()
+
+	go client.sendLoop()
+	// 留出时间让sendLoop空闲阻塞在最初的队列上
+	// give sendLoop time to idle-block on the original queue
+	time.Sleep(10 * time.Millisecond)
+
+	client.SetSendQueue(4, Block)
+	assert.NoError(t, client.SendMsg(1, []byte("a")))
+
+	assert.Eventually(t, func() bool {
+		return client.PendingCount() == 0
+	}, time.Second, 5*time.Millisecond, "sendLoop should dequeue from the new queue after SetSendQueue swapped it while idle")
+}
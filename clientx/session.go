@@ -0,0 +1,63 @@
+package clientx
+
+import (
+	"github.com/aceld/zinx/ziface"
+)
+
+// SetSessionProperty 设置一个会话属性，该属性在底层连接重连后依然保留
+// SetSessionProperty sets a session property that survives reconnects of the underlying connection
+func (c *Client) SetSessionProperty(key string, value any) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	if c.sessionProperties == nil {
+		c.sessionProperties = make(map[string]any)
+	}
+	c.sessionProperties[key] = value
+}
+
+// GetSessionProperty 获取一个会话属性
+// GetSessionProperty gets a session property
+func (c *Client) GetSessionProperty(key string) (any, bool) {
+	c.sessionMu.RLock()
+	defer c.sessionMu.RUnlock()
+	value, ok := c.sessionProperties[key]
+	return value, ok
+}
+
+// RemoveSessionProperty 删除一个会话属性
+// RemoveSessionProperty removes a session property
+func (c *Client) RemoveSessionProperty(key string) {
+	c.sessionMu.Lock()
+	defer c.sessionMu.Unlock()
+	delete(c.sessionProperties, key)
+}
+
+// SetOnReattach 设置连接（首次建立或重连）建立后的回调，此时会话属性已经被恢复到新连接上
+// SetOnReattach sets the callback invoked after a connection (initial or reconnect) is established,
+// by which point session properties have already been restored onto the new connection
+func (c *Client) SetOnReattach(handler func(conn ziface.IConnection, isReconnect bool)) {
+	c.onReattachMu.Lock()
+	defer c.onReattachMu.Unlock()
+	c.onReattach = handler
+}
+
+// restoreSessionProperties 将所有会话属性拷贝到新的 IConnection 上
+// restoreSessionProperties copies all session properties onto the new IConnection
+func (c *Client) restoreSessionProperties(conn ziface.IConnection) {
+	c.sessionMu.RLock()
+	defer c.sessionMu.RUnlock()
+	for key, value := range c.sessionProperties {
+		conn.SetProperty(key, value)
+	}
+}
+
+// fireOnReattach 在会话属性恢复之后调用用户设置的 OnReattach 钩子
+// fireOnReattach invokes the user's OnReattach hook after session properties have been restored
+func (c *Client) fireOnReattach(conn ziface.IConnection, isReconnect bool) {
+	c.onReattachMu.Lock()
+	handler := c.onReattach
+	c.onReattachMu.Unlock()
+	if handler != nil {
+		handler(conn, isReconnect)
+	}
+}
@@ -0,0 +1,58 @@
+package clientx
+
+import (
+	"testing"
+
+	"github.com/aceld/zinx/ziface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionProperty(t *testing.T) {
+	client := NewClient("localhost", 8080)
+
+	_, ok := client.GetSessionProperty("uid")
+	assert.False(t, ok)
+
+	client.SetSessionProperty("uid", 1001)
+	value, ok := client.GetSessionProperty("uid")
+	assert.True(t, ok)
+	assert.Equal(t, 1001, value)
+
+	client.RemoveSessionProperty("uid")
+	_, ok = client.GetSessionProperty("uid")
+	assert.False(t, ok)
+}
+
+func TestSetOnReattach(t *testing.T) {
+	client := NewClient("localhost", 8080)
+
+	var calledReconnect bool
+	client.SetOnReattach(func(conn ziface.IConnection, isReconnect bool) {
+		calledReconnect = isReconnect
+	})
+
+	client.fireOnReattach(nil, true)
+	assert.True(t, calledReconnect)
+}
+
+func TestOnReattachRunsBeforeSendQueueIsReleased(t *testing.T) {
+	client := NewClient("localhost", 8080)
+
+	var gateOpenDuringReattach bool
+	client.SetOnReattach(func(conn ziface.IConnection, isReconnect bool) {
+		select {
+		case <-client.connGate:
+			gateOpenDuringReattach = true
+		default:
+		}
+	})
+
+	client.onConnStart(nil)
+	assert.False(t, gateOpenDuringReattach, "send queue must stay gated until OnReattach has run")
+
+	select {
+	case <-client.connGate:
+	default:
+		t.Fatal("send queue should be released once onConnStart has finished")
+	}
+}